@@ -1,27 +1,43 @@
 package prometheus
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 
 	g "github.com/onsi/ginkgo"
 	o "github.com/onsi/gomega"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
 
 	v1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
 	kapierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	kapi "k8s.io/kubernetes/pkg/apis/core"
 	"k8s.io/kubernetes/pkg/client/conditions"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
@@ -31,17 +47,151 @@ import (
 
 const waitForPrometheusStartSeconds = 240
 
+// how long to poll a PromQL query waiting for it to settle on an expected value
+const prometheusMetricValidationDuration = 2 * time.Minute
+
+// relative error allowed between a query result and the expected value
+const prometheusMetricErrorTolerance = 0.25
+
+// prometheusProxyPath is the API server service-proxy subresource that
+// fronts the secured Prometheus route, so tests can reach Prometheus
+// without scheduling an execpod or shelling out to curl.
+const prometheusProxyPath = "/api/v1/namespaces/openshift-monitoring/services/https:prometheus-k8s:web/proxy"
+
+// prometheusClient issues requests to Prometheus through the API
+// server's service proxy, so the test driver needs no cluster-network
+// reachability of its own.
+type prometheusClient struct {
+	restClient rest.Interface
+	httpClient *http.Client
+	baseURL    string
+
+	// anonRestClient/anonHTTPClient carry no credentials, for genuinely anonymous requests.
+	anonRestClient rest.Interface
+	anonHTTPClient *http.Client
+}
+
+// newPrometheusClient builds a prometheusClient using in-cluster config
+// when available, falling back to the kubeconfig backing oc, and trusts
+// the openshift-service-ca.crt ConfigMap's serving certificate.
+func newPrometheusClient(oc *exutil.CLI) (*prometheusClient, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		config = rest.CopyConfig(oc.AdminConfig())
+	}
+
+	if cm, err := oc.AdminKubeClient().Core().ConfigMaps("openshift-monitoring").Get("openshift-service-ca.crt", metav1.GetOptions{}); err == nil {
+		config.TLSClientConfig = rest.TLSClientConfig{CAData: []byte(cm.Data["service-ca.crt"])}
+	}
+
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	restConfig := rest.CopyConfig(config)
+	restConfig.GroupVersion = &schema.GroupVersion{}
+	restConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	restConfig.APIPath = "/api"
+	restClient, err := rest.RESTClientFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	anonConfig := rest.CopyConfig(restConfig)
+	anonConfig.BearerToken = ""
+	anonConfig.BearerTokenFile = ""
+	anonConfig.Username = ""
+	anonConfig.Password = ""
+	anonConfig.AuthProvider = nil
+	anonConfig.AuthConfigPersister = nil
+	anonConfig.ExecProvider = nil
+	anonConfig.WrapTransport = nil
+	anonConfig.TLSClientConfig.CertData = nil
+	anonConfig.TLSClientConfig.KeyData = nil
+	anonConfig.TLSClientConfig.CertFile = ""
+	anonConfig.TLSClientConfig.KeyFile = ""
+	anonRestClient, err := rest.RESTClientFor(anonConfig)
+	if err != nil {
+		return nil, err
+	}
+	anonHTTPClient, err := rest.HTTPClientFor(anonConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prometheusClient{
+		restClient:     restClient,
+		httpClient:     httpClient,
+		baseURL:        strings.TrimSuffix(config.Host, "/"),
+		anonRestClient: anonRestClient,
+		anonHTTPClient: anonHTTPClient,
+	}, nil
+}
+
+// get issues an authenticated GET for path (e.g. "/api/v1/targets")
+// against Prometheus and returns the raw response body.
+func (p *prometheusClient) get(path, bearerToken string) ([]byte, error) {
+	req := p.restClient.Get().AbsPath(prometheusProxyPath + path)
+	req = req.SetHeader("Authorization", "Bearer "+bearerToken)
+	return req.DoRaw(context.Background())
+}
+
+// getStatusCode issues a GET for path and returns the HTTP status code
+// Prometheus (or its oauth-proxy) responded with. An empty bearerToken
+// routes the request through the credential-free client instead of
+// merely omitting the Authorization header.
+func (p *prometheusClient) getStatusCode(path, bearerToken string) (int, error) {
+	restClient := p.restClient
+	if len(bearerToken) == 0 {
+		restClient = p.anonRestClient
+	}
+	req := restClient.Get().AbsPath(prometheusProxyPath + path)
+	if len(bearerToken) > 0 {
+		req = req.SetHeader("Authorization", "Bearer "+bearerToken)
+	}
+	result := req.Do(context.Background())
+	var statusCode int
+	result.StatusCode(&statusCode)
+	if statusCode != 0 {
+		// a non-2xx response is still a response: report its code, not an error
+		return statusCode, nil
+	}
+	return 0, result.Error()
+}
+
+// scrape issues a GET for path with the given Accept header and returns
+// the raw *http.Response so the caller can inspect Content-Type before
+// decoding; unlike get(), the caller must close the response body. An
+// empty bearerToken routes the request through the credential-free client.
+func (p *prometheusClient) scrape(path, bearerToken, accept string) (*http.Response, error) {
+	httpClient := p.httpClient
+	if len(bearerToken) == 0 {
+		httpClient = p.anonHTTPClient
+	}
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+prometheusProxyPath+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if len(bearerToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+	return httpClient.Do(req)
+}
+
 var _ = g.Describe("[Feature:Prometheus][Conformance] Prometheus", func() {
 	defer g.GinkgoRecover()
 	var (
 		oc = exutil.NewCLIWithoutNamespace("prometheus")
 
-		url, bearerToken string
+		client      *prometheusClient
+		bearerToken string
 	)
 
 	g.BeforeEach(func() {
 		var ok bool
-		url, bearerToken, ok = locatePrometheus(oc)
+		client, bearerToken, ok = locatePrometheus(oc)
 		if !ok {
 			e2e.Skipf("Prometheus could not be located on this cluster, skipping prometheus test")
 		}
@@ -52,11 +202,6 @@ var _ = g.Describe("[Feature:Prometheus][Conformance] Prometheus", func() {
 			if !hasPullSecret(oc.AdminKubeClient(), "cloud.openshift.com") {
 				e2e.Skipf("Telemetry is disabled")
 			}
-			oc.SetupProject()
-			ns := oc.Namespace()
-
-			execPodName := e2e.CreateExecPodOrFail(oc.AdminKubeClient(), ns, "execpod", func(pod *v1.Pod) { pod.Spec.Containers[0].Image = "centos:7" })
-			defer func() { oc.AdminKubeClient().Core().Pods(ns).Delete(execPodName, metav1.NewDeleteOptions(1)) }()
 
 			tests := map[string][]metricTest{
 				// should have successfully sent at least once to remote
@@ -64,29 +209,21 @@ var _ = g.Describe("[Feature:Prometheus][Conformance] Prometheus", func() {
 				// should have scraped some metrics from prometheus
 				`federate_samples{job="telemeter-client"}`: {metricTest{greaterThanEqual: true, value: 10}},
 			}
-			runQueries(tests, oc, ns, execPodName, url, bearerToken)
+			runQueries(tests, client, bearerToken)
 
 			e2e.Logf("Telemetry is enabled: %s", bearerToken)
 		})
 
 		g.It("should start and expose a secured proxy and unsecured metrics", func() {
-			oc.SetupProject()
-			ns := oc.Namespace()
-			execPodName := e2e.CreateExecPodOrFail(oc.AdminKubeClient(), ns, "execpod", func(pod *v1.Pod) { pod.Spec.Containers[0].Image = "centos:7" })
-			defer func() { oc.AdminKubeClient().Core().Pods(ns).Delete(execPodName, metav1.NewDeleteOptions(1)) }()
-
 			g.By("checking the unsecured metrics path")
 			var metrics map[string]*dto.MetricFamily
 			o.Expect(wait.PollImmediate(10*time.Second, waitForPrometheusStartSeconds*time.Second, func() (bool, error) {
-				results, err := getInsecureURLViaPod(ns, execPodName, fmt.Sprintf("%s/metrics", url))
+				var err error
+				metrics, err = scrapeMetricFamilies(client, "/metrics", "")
 				if err != nil {
-					e2e.Logf("unable to get unsecured metrics: %v", err)
+					e2e.Logf("unable to scrape unsecured metrics: %v", err)
 					return false, nil
 				}
-
-				p := expfmt.TextParser{}
-				metrics, err = p.TextToMetricFamilies(bytes.NewBufferString(results))
-				o.Expect(err).NotTo(o.HaveOccurred())
 				// original field in 2.0.0-beta
 				counts := findCountersWithLabels(metrics["tsdb_samples_appended_total"], labels{})
 				if len(counts) != 0 && counts[0] > 0 {
@@ -103,25 +240,27 @@ var _ = g.Describe("[Feature:Prometheus][Conformance] Prometheus", func() {
 					return true, nil
 				}
 				return false, nil
-			})).NotTo(o.HaveOccurred(), fmt.Sprintf("Did not find tsdb_samples_appended_total, tsdb_head_samples_appended_total, or prometheus_tsdb_head_samples_appended_total"))
+			})).NotTo(o.HaveOccurred(), "Did not find tsdb_samples_appended_total, tsdb_head_samples_appended_total, or prometheus_tsdb_head_samples_appended_total")
 
 			g.By("verifying the oauth-proxy reports a 403 on the root URL")
-			err := expectURLStatusCodeExec(ns, execPodName, url, 403)
+			statusCode, err := client.getStatusCode("/", "")
 			o.Expect(err).NotTo(o.HaveOccurred())
+			o.Expect(statusCode).To(o.Equal(403))
 
 			g.By("verifying a service account token is able to authenticate")
-			err = expectBearerTokenURLStatusCodeExec(ns, execPodName, fmt.Sprintf("%s/graph", url), bearerToken, 200)
+			statusCode, err = client.getStatusCode("/graph", bearerToken)
 			o.Expect(err).NotTo(o.HaveOccurred())
+			o.Expect(statusCode).To(o.Equal(200))
 
 			g.By("verifying a service account token is able to access the Prometheus API")
 			// expect all endpoints within 60 seconds
 			var lastErrs []error
 			o.Expect(wait.PollImmediate(10*time.Second, 2*time.Minute, func() (bool, error) {
-				contents, err := getBearerTokenURLViaPod(ns, execPodName, fmt.Sprintf("%s/api/v1/targets", url), bearerToken)
+				contents, err := client.get("/api/v1/targets", bearerToken)
 				o.Expect(err).NotTo(o.HaveOccurred())
 
 				targets := &prometheusTargets{}
-				err = json.Unmarshal([]byte(contents), targets)
+				err = json.Unmarshal(contents, targets)
 				o.Expect(err).NotTo(o.HaveOccurred())
 
 				g.By("verifying all expected jobs have a working target")
@@ -147,9 +286,115 @@ var _ = g.Describe("[Feature:Prometheus][Conformance] Prometheus", func() {
 				return true, nil
 			})).NotTo(o.HaveOccurred())
 		})
+		g.It("should have a scrape target for every pod and service annotated for prometheus.io/scrape", func() {
+			o.Expect(wait.PollImmediate(10*time.Second, 2*time.Minute, func() (bool, error) {
+				discovered, err := DiscoveredTargets(oc, client, bearerToken)
+				if err != nil {
+					return false, err
+				}
+				if err := discovered.AllUp(); err != nil {
+					e2e.Logf("not all discovered scrape targets are up yet: %v", err)
+					return false, nil
+				}
+				return true, nil
+			})).NotTo(o.HaveOccurred())
+		})
+	})
+
+	g.Describe("rules and alerts", func() {
+		g.It("should have the expected rule groups loaded and healthy", func() {
+			var rules *prometheusRules
+			o.Expect(wait.PollImmediate(10*time.Second, waitForPrometheusStartSeconds*time.Second, func() (bool, error) {
+				var err error
+				rules, err = getPrometheusRules(client, bearerToken)
+				if err != nil {
+					return false, err
+				}
+				for _, name := range expectedRuleGroups {
+					if !rules.HasGroup(name) {
+						e2e.Logf("rule group %q not loaded yet", name)
+						return false, nil
+					}
+				}
+				if unhealthy := rules.UnhealthyRules(); len(unhealthy) > 0 {
+					e2e.Logf("rules not healthy yet: %v", unhealthy)
+					return false, nil
+				}
+				return true, nil
+			})).NotTo(o.HaveOccurred())
+
+			g.By("verifying all expected rule groups are loaded")
+			for _, name := range expectedRuleGroups {
+				o.Expect(rules.HasGroup(name)).To(o.BeTrue(), fmt.Sprintf("expected rule group %q to be loaded", name))
+			}
+
+			g.By("verifying no rule is unhealthy")
+			o.Expect(rules.UnhealthyRules()).To(o.BeEmpty())
+		})
+
+		g.It("should not have any unexpected alerts firing at steady state", func() {
+			var alerts *prometheusAlerts
+			o.Expect(wait.PollImmediate(10*time.Second, waitForPrometheusStartSeconds*time.Second, func() (bool, error) {
+				var err error
+				alerts, err = getPrometheusAlerts(client, bearerToken)
+				if err != nil {
+					return false, err
+				}
+				if firing := alerts.Firing(allowedFiringAlerts); len(firing) > 0 {
+					e2e.Logf("unexpected alerts still firing: %v", firing)
+					return false, nil
+				}
+				return true, nil
+			})).NotTo(o.HaveOccurred())
+
+			o.Expect(alerts.Firing(allowedFiringAlerts)).To(o.BeEmpty())
+		})
+
+		g.It("should fire an alert when a monitored workload becomes unreachable", func() {
+			ns, name := "openshift-monitoring", "grafana"
+
+			g.By(fmt.Sprintf("blocking ingress to %s/%s to synthesize a TargetDown condition", ns, name))
+			restore, err := blockScrapeTraffic(oc.AdminKubeClient(), ns, name)
+			o.Expect(err).NotTo(o.HaveOccurred())
+			defer restore()
+
+			g.By("waiting for the TargetDown alert to fire")
+			o.Expect(waitForAlertFiring(client, bearerToken, "TargetDown", 5*time.Minute)).NotTo(o.HaveOccurred())
+		})
+	})
+
+	g.Describe("SLO assertions", func() {
+		g.It("should keep the apiserver within its request rate and error budget SLOs", func() {
+			g.By("checking the apiserver is serving a non-zero request rate")
+			o.Expect(expectRate(client, bearerToken, `apiserver_request_total`, "5m", 0.01)).NotTo(o.HaveOccurred())
+
+			g.By("checking the apiserver 5xx error budget hasn't been burned over the last 5m")
+			o.Expect(expectNoErrorBudgetBurn(client, bearerToken, "apiserver", "5m", 0.05)).NotTo(o.HaveOccurred())
+
+			g.By("checking the apiserver request rate over the last 5m")
+			end := time.Now()
+			matrix, err := queryPrometheusRange(client, bearerToken, `sum(rate(apiserver_request_total[5m]))`, end.Add(-5*time.Minute), end, 30*time.Second)
+			o.Expect(err).NotTo(o.HaveOccurred())
+			o.Expect(matrix).NotTo(o.BeEmpty())
+		})
 	})
 })
 
+// expectedRuleGroups is the set of recording/alerting rule groups that
+// must always be loaded in a running cluster.
+var expectedRuleGroups = []string{
+	"kubernetes-apps",
+	"kubernetes-resources",
+	"kubernetes-system-apiserver",
+	"node-exporter",
+	"prometheus",
+}
+
+// allowedFiringAlerts may legitimately be firing at steady state.
+var allowedFiringAlerts = map[string]bool{
+	"Watchdog": true,
+}
+
 func all(errs ...error) []error {
 	var result []error
 	for _, err := range errs {
@@ -194,6 +439,277 @@ func (t *prometheusTargets) Expect(l labels, health, scrapeURLPattern string) er
 	return fmt.Errorf("no match for %v with health %s and scrape URL %s", l, health, scrapeURLPattern)
 }
 
+const (
+	prometheusScrapeAnnotation = "prometheus.io/scrape"
+	prometheusPortAnnotation   = "prometheus.io/port"
+	prometheusPathAnnotation   = "prometheus.io/path"
+	prometheusSchemeAnnotation = "prometheus.io/scheme"
+)
+
+// discoveryDenylist excludes namespace/name pairs that carry the
+// prometheus.io/scrape annotation but are not expected to appear as a
+// conformance-required target, e.g. short-lived jobs or test fixtures.
+var discoveryDenylist = map[string]bool{}
+
+// scrapeTarget is a pod or service discovered via prometheus.io/scrape
+// annotations that we expect Prometheus to have picked up. kind is the
+// label key ("pod" or "service") Prometheus attaches identifying it.
+type scrapeTarget struct {
+	namespace, name string
+	kind            string
+	scheme, path    string
+	port            string
+}
+
+// discoveredTargets pairs the scrape targets discovered from cluster
+// annotations with the activeTargets Prometheus actually reports.
+type discoveredTargets struct {
+	targets       *prometheusTargets
+	scrapeTargets []scrapeTarget
+}
+
+// DiscoveredTargets walks every pod and service in the cluster carrying a
+// prometheus.io/scrape=true annotation and pairs them with the current
+// /api/v1/targets response, so a test can assert each discovered target
+// is actually being scraped successfully without hardcoding a job list.
+func DiscoveredTargets(oc *exutil.CLI, client *prometheusClient, bearerToken string) (*discoveredTargets, error) {
+	contents, err := client.get("/api/v1/targets", bearerToken)
+	if err != nil {
+		return nil, err
+	}
+	targets := &prometheusTargets{}
+	if err := json.Unmarshal(contents, targets); err != nil {
+		return nil, err
+	}
+
+	scrapeTargets, err := discoverScrapeTargets(oc.AdminKubeClient())
+	if err != nil {
+		return nil, err
+	}
+
+	return &discoveredTargets{targets: targets, scrapeTargets: scrapeTargets}, nil
+}
+
+// discoverScrapeTargets lists every pod and service in the cluster and
+// returns those annotated for Prometheus scraping.
+func discoverScrapeTargets(client clientset.Interface) ([]scrapeTarget, error) {
+	var result []scrapeTarget
+
+	pods, err := client.Core().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		if t, ok := scrapeTargetFromAnnotations("pod", pod.Namespace, pod.Name, pod.Annotations); ok {
+			result = append(result, t)
+		}
+	}
+
+	services, err := client.Core().Services(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range services.Items {
+		if t, ok := scrapeTargetFromAnnotations("service", svc.Namespace, svc.Name, svc.Annotations); ok {
+			result = append(result, t)
+		}
+	}
+
+	return result, nil
+}
+
+func scrapeTargetFromAnnotations(kind, namespace, name string, annotations map[string]string) (scrapeTarget, bool) {
+	if annotations[prometheusScrapeAnnotation] != "true" {
+		return scrapeTarget{}, false
+	}
+	if discoveryDenylist[namespace+"/"+name] {
+		return scrapeTarget{}, false
+	}
+	scheme := annotations[prometheusSchemeAnnotation]
+	if scheme == "" {
+		scheme = "http"
+	}
+	path := annotations[prometheusPathAnnotation]
+	if path == "" {
+		path = "/metrics"
+	}
+	return scrapeTarget{
+		namespace: namespace,
+		name:      name,
+		kind:      kind,
+		scheme:    scheme,
+		path:      path,
+		port:      annotations[prometheusPortAnnotation],
+	}, true
+}
+
+// AllUp returns an error unless every discovered scrape target has a
+// matching activeTarget reporting health=up. Each target is matched by
+// namespace and pod/service name (not just namespace), and by its
+// annotated port when set, so a genuinely down target among several
+// scraped workloads in the same namespace can't hide behind a sibling's
+// healthy one.
+func (d *discoveredTargets) AllUp() error {
+	var errs []error
+	for _, target := range d.scrapeTargets {
+		pattern := fmt.Sprintf("^%s://[^/]*%s%s$", regexp.QuoteMeta(target.scheme), portPattern(target.port), regexp.QuoteMeta(target.path))
+		l := labels{"namespace": target.namespace, target.kind: target.name}
+		if err := d.targets.Expect(l, "up", pattern); err != nil {
+			errs = append(errs, fmt.Errorf("discovered scrape target %s/%s: %v", target.namespace, target.name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("not all discovered scrape targets are up: %v", errs)
+	}
+	return nil
+}
+
+func portPattern(port string) string {
+	if port == "" {
+		return ""
+	}
+	return ":" + regexp.QuoteMeta(port)
+}
+
+// prometheusRules is the decoded response of /api/v1/rules.
+type prometheusRules struct {
+	Data struct {
+		Groups []struct {
+			Name  string `json:"name"`
+			File  string `json:"file"`
+			Rules []struct {
+				Name   string `json:"name"`
+				Query  string `json:"query"`
+				Type   string `json:"type"`
+				Health string `json:"health"`
+			} `json:"rules"`
+		} `json:"groups"`
+	} `json:"data"`
+	Status string `json:"status"`
+}
+
+// HasGroup reports whether a rule group with the given name is loaded.
+func (r *prometheusRules) HasGroup(name string) bool {
+	for _, group := range r.Data.Groups {
+		if group.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnhealthyRules returns the qualified names ("group/rule") of every
+// rule whose reported health is not "ok".
+func (r *prometheusRules) UnhealthyRules() []string {
+	var result []string
+	for _, group := range r.Data.Groups {
+		for _, rule := range group.Rules {
+			if rule.Health != "ok" {
+				result = append(result, fmt.Sprintf("%s/%s", group.Name, rule.Name))
+			}
+		}
+	}
+	return result
+}
+
+// prometheusAlerts is the decoded response of /api/v1/alerts.
+type prometheusAlerts struct {
+	Data struct {
+		Alerts []struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+			State       string            `json:"state"`
+			ActiveAt    time.Time         `json:"activeAt"`
+			Value       string            `json:"value"`
+		} `json:"alerts"`
+	} `json:"data"`
+	Status string `json:"status"`
+}
+
+// Firing returns the names of firing alerts not present in allowlist.
+func (a *prometheusAlerts) Firing(allowlist map[string]bool) []string {
+	var result []string
+	for _, alert := range a.Data.Alerts {
+		if alert.State != "firing" {
+			continue
+		}
+		name := alert.Labels["alertname"]
+		if allowlist[name] {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+func getPrometheusRules(client *prometheusClient, bearerToken string) (*prometheusRules, error) {
+	contents, err := client.get("/api/v1/rules", bearerToken)
+	if err != nil {
+		return nil, err
+	}
+	rules := &prometheusRules{}
+	if err := json.Unmarshal(contents, rules); err != nil {
+		return nil, fmt.Errorf("unable to parse rules response: %v", err)
+	}
+	return rules, nil
+}
+
+func getPrometheusAlerts(client *prometheusClient, bearerToken string) (*prometheusAlerts, error) {
+	contents, err := client.get("/api/v1/alerts", bearerToken)
+	if err != nil {
+		return nil, err
+	}
+	alerts := &prometheusAlerts{}
+	if err := json.Unmarshal(contents, alerts); err != nil {
+		return nil, fmt.Errorf("unable to parse alerts response: %v", err)
+	}
+	return alerts, nil
+}
+
+// waitForAlertFiring polls /api/v1/alerts until alertName is firing or timeout elapses
+func waitForAlertFiring(client *prometheusClient, bearerToken, alertName string, timeout time.Duration) error {
+	return wait.PollImmediate(10*time.Second, timeout, func() (bool, error) {
+		alerts, err := getPrometheusAlerts(client, bearerToken)
+		if err != nil {
+			e2e.Logf("unable to get alerts while waiting for %s to fire: %v", alertName, err)
+			return false, nil
+		}
+		for _, name := range alerts.Firing(nil) {
+			if name == alertName {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// blockScrapeTraffic denies all ingress to the Pods backing the named
+// Deployment by creating a deny-all NetworkPolicy selecting them. Unlike
+// scaling the Deployment to zero, the Pods (and therefore the Endpoints
+// Prometheus discovers them through) are left in place -- only the
+// traffic reaching them is cut -- so the scrape target stays
+// discoverable and its up series reports 0 instead of going stale, the
+// way a genuinely unreachable workload would behave. Returns a func that
+// removes the policy.
+func blockScrapeTraffic(client clientset.Interface, ns, name string) (func(), error) {
+	d, err := client.Extensions().Deployments(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	policy := &extensions.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-" + name + "-scrape"},
+		Spec: extensions.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: d.Spec.Selector.MatchLabels},
+		},
+	}
+	if _, err := client.Extensions().NetworkPolicies(ns).Create(policy); err != nil {
+		return nil, err
+	}
+	return func() {
+		client.Extensions().NetworkPolicies(ns).Delete(policy.Name, &metav1.DeleteOptions{})
+	}, nil
+}
+
 type labels map[string]string
 
 func (l labels) With(name, value string) labels {
@@ -205,6 +721,297 @@ func (l labels) With(name, value string) labels {
 	return n
 }
 
+// key returns a canonical string for l suitable for use as a map key.
+func (l labels) key() string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, l[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// receivedSeries is everything remoteWriteReceiver recorded for a single
+// time series it was sent.
+type receivedSeries struct {
+	labels      labels
+	sampleCount int
+	lastSample  time.Time
+}
+
+// remoteWriteReceiver is a minimal Prometheus remote-write endpoint: it
+// decodes the snappy-compressed protobuf WriteRequest every sender
+// issues and records the label sets and sample counts it saw, so a test
+// can assert that specific series actually arrived rather than just
+// that a POST succeeded.
+type remoteWriteReceiver struct {
+	mu     sync.Mutex
+	series map[string]*receivedSeries
+	server *httptest.Server
+}
+
+// newRemoteWriteReceiver starts listening for remote-write requests.
+// Callers must Close() it when done.
+func newRemoteWriteReceiver() *remoteWriteReceiver {
+	r := &remoteWriteReceiver{series: map[string]*receivedSeries{}}
+	r.server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+// URL is the address senders should remote_write to.
+func (r *remoteWriteReceiver) URL() string {
+	return r.server.URL
+}
+
+// Close stops the receiver.
+func (r *remoteWriteReceiver) Close() {
+	r.server.Close()
+}
+
+func (r *remoteWriteReceiver) handle(w http.ResponseWriter, req *http.Request) {
+	compressed, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	decompressed, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var writeReq prompb.WriteRequest
+	if err := proto.Unmarshal(decompressed, &writeReq); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ts := range writeReq.Timeseries {
+		l := make(labels, len(ts.Labels))
+		for _, label := range ts.Labels {
+			l[label.Name] = label.Value
+		}
+		series, ok := r.series[l.key()]
+		if !ok {
+			series = &receivedSeries{labels: l}
+			r.series[l.key()] = series
+		}
+		for _, sample := range ts.Samples {
+			series.sampleCount++
+			sampleTime := time.Unix(0, sample.Timestamp*int64(time.Millisecond))
+			if sampleTime.After(series.lastSample) {
+				series.lastSample = sampleTime
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Expect asserts that a series matching l arrived with at least
+// minSamples samples, the most recent no older than maxStaleness,
+// mirroring prometheusTargets.Expect.
+func (r *remoteWriteReceiver) Expect(l labels, minSamples int, maxStaleness time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, series := range r.series {
+		match := true
+		for k, v := range l {
+			if series.labels[k] != v {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if series.sampleCount < minSamples {
+			continue
+		}
+		if time.Since(series.lastSample) > maxStaleness {
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("no remote-write series matching %v with >= %d samples seen within %s", l, minSamples, maxStaleness)
+}
+
+// envelope returned by both /api/v1/query and /api/v1/query_range
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+	Error     string `json:"error"`
+	ErrorType string `json:"errorType"`
+}
+
+// queryPrometheus runs an instant PromQL query as of at (zero value means "now")
+func queryPrometheus(client *prometheusClient, bearerToken, promQL string, at time.Time) (model.Vector, error) {
+	path := fmt.Sprintf("/api/v1/query?%s", (neturl.Values{
+		"query": []string{promQL},
+		"time":  []string{formatQueryTime(at)},
+	}).Encode())
+	contents, err := client.get(path, bearerToken)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute query %q: %v", promQL, err)
+	}
+	return parsePrometheusVector(contents)
+}
+
+// queryPrometheusRange runs a PromQL range query over [start, end] at step
+func queryPrometheusRange(client *prometheusClient, bearerToken, promQL string, start, end time.Time, step time.Duration) (model.Matrix, error) {
+	path := fmt.Sprintf("/api/v1/query_range?%s", (neturl.Values{
+		"query": []string{promQL},
+		"start": []string{formatQueryTime(start)},
+		"end":   []string{formatQueryTime(end)},
+		"step":  []string{strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}).Encode())
+	contents, err := client.get(path, bearerToken)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute range query %q: %v", promQL, err)
+	}
+	var resp prometheusResponse
+	if err := json.Unmarshal(contents, &resp); err != nil {
+		return nil, fmt.Errorf("unable to parse query_range response: %v", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("query_range returned %s: %s", resp.ErrorType, resp.Error)
+	}
+	var matrix model.Matrix
+	if err := json.Unmarshal(resp.Data.Result, &matrix); err != nil {
+		return nil, fmt.Errorf("unable to parse query_range result: %v", err)
+	}
+	return matrix, nil
+}
+
+func parsePrometheusVector(contents []byte) (model.Vector, error) {
+	var resp prometheusResponse
+	if err := json.Unmarshal(contents, &resp); err != nil {
+		return nil, fmt.Errorf("unable to parse query response: %v", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("query returned %s: %s", resp.ErrorType, resp.Error)
+	}
+	var vector model.Vector
+	if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+		return nil, fmt.Errorf("unable to parse query result: %v", err)
+	}
+	return vector, nil
+}
+
+func formatQueryTime(at time.Time) string {
+	if at.IsZero() {
+		return strconv.FormatFloat(float64(time.Now().Unix()), 'f', -1, 64)
+	}
+	return strconv.FormatFloat(float64(at.Unix()), 'f', -1, 64)
+}
+
+// metricTest describes an expected value for a PromQL query result
+type metricTest struct {
+	greaterThanEqual bool
+	lessThanEqual    bool
+	value            float64
+}
+
+func (t metricTest) matches(got float64) bool {
+	switch {
+	case t.greaterThanEqual:
+		return got >= t.value
+	case t.lessThanEqual:
+		return got <= t.value
+	case t.value == 0:
+		return got == 0
+	default:
+		return math.Abs(got-t.value)/t.value < prometheusMetricErrorTolerance
+	}
+}
+
+func (t metricTest) String() string {
+	switch {
+	case t.greaterThanEqual:
+		return fmt.Sprintf(">= %v", t.value)
+	case t.lessThanEqual:
+		return fmt.Sprintf("<= %v", t.value)
+	default:
+		return fmt.Sprintf("~= %v (+/- %.0f%%)", t.value, prometheusMetricErrorTolerance*100)
+	}
+}
+
+// runQueries polls each query in tests until its metricTests are satisfied
+// or prometheusMetricValidationDuration elapses
+func runQueries(tests map[string][]metricTest, client *prometheusClient, bearerToken string) {
+	var lastErrs []error
+	o.Expect(wait.PollImmediate(10*time.Second, prometheusMetricValidationDuration, func() (bool, error) {
+		lastErrs = nil
+		for query, expected := range tests {
+			vector, err := queryPrometheus(client, bearerToken, query, time.Time{})
+			if err != nil {
+				lastErrs = append(lastErrs, err)
+				continue
+			}
+			if len(vector) == 0 {
+				lastErrs = append(lastErrs, fmt.Errorf("query %q returned no samples", query))
+				continue
+			}
+			got := float64(vector[0].Value)
+			for _, test := range expected {
+				if !test.matches(got) {
+					lastErrs = append(lastErrs, fmt.Errorf("query %q returned %v, expected %s", query, got, test))
+				}
+			}
+		}
+		if len(lastErrs) > 0 {
+			e2e.Logf("still waiting on prometheus queries: %v", lastErrs)
+			return false, nil
+		}
+		return true, nil
+	})).NotTo(o.HaveOccurred(), fmt.Sprintf("failed to confirm prometheus queries: %v", lastErrs))
+}
+
+// expectRate asserts that sum(rate(selector[window])) is at least min.
+// selector is summed across all its label dimensions so the check is
+// against the metric's aggregate rate, not one arbitrarily-ordered series.
+func expectRate(client *prometheusClient, bearerToken, selector, window string, min float64) error {
+	promQL := fmt.Sprintf("sum(rate(%s[%s]))", selector, window)
+	vector, err := queryPrometheus(client, bearerToken, promQL, time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(vector) == 0 {
+		return fmt.Errorf("query %q returned no samples", promQL)
+	}
+	if got := float64(vector[0].Value); got < min {
+		return fmt.Errorf("sum(rate(%s[%s])) was %v, expected >= %v", selector, window, got, min)
+	}
+	return nil
+}
+
+// expectNoErrorBudgetBurn asserts job's 5xx error ratio over window stays under maxBurnRate
+func expectNoErrorBudgetBurn(client *prometheusClient, bearerToken, job, window string, maxBurnRate float64) error {
+	promQL := fmt.Sprintf(
+		`sum(rate(apiserver_request_total{job=%q,code=~"5.."}[%s])) / sum(rate(apiserver_request_total{job=%q}[%s]))`,
+		job, window, job, window,
+	)
+	vector, err := queryPrometheus(client, bearerToken, promQL, time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(vector) == 0 {
+		// no traffic at all during the window is not a budget burn
+		return nil
+	}
+	if got := float64(vector[0].Value); got > maxBurnRate {
+		return fmt.Errorf("job %s error ratio over %s was %v, expected <= %v", job, window, got, maxBurnRate)
+	}
+	return nil
+}
+
 func findEnvVar(vars []kapi.EnvVar, key string) string {
 	for _, v := range vars {
 		if v.Name == key {
@@ -266,55 +1073,46 @@ func findMetricLabels(f *dto.MetricFamily, labels map[string]string, match strin
 	return result
 }
 
-func expectURLStatusCodeExec(ns, execPodName, url string, statusCode int) error {
-	cmd := fmt.Sprintf("curl -k -s -o /dev/null -w '%%{http_code}' %q", url)
-	output, err := e2e.RunHostCmd(ns, execPodName, cmd)
-	if err != nil {
-		return fmt.Errorf("host command failed: %v\n%s", err, output)
-	}
-	if output != strconv.Itoa(statusCode) {
-		return fmt.Errorf("last response from server was not %d: %s", statusCode, output)
-	}
-	return nil
-}
+// prefer protobuf-delimited, fall back to text; every Prometheus version serves one
+const scrapeAcceptHeader = `application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited; q=0.7, text/plain;version=0.0.4;q=0.3`
 
-func expectBearerTokenURLStatusCodeExec(ns, execPodName, url, bearer string, statusCode int) error {
-	cmd := fmt.Sprintf("curl -k -s -H 'Authorization: Bearer %s' -o /dev/null -w '%%{http_code}' %q", bearer, url)
-	output, err := e2e.RunHostCmd(ns, execPodName, cmd)
+// scrapeMetricFamilies fetches path (typically "/metrics") and decodes the
+// response into its MetricFamily set, keyed by metric name, dispatching on
+// the response Content-Type.
+func scrapeMetricFamilies(client *prometheusClient, path, bearerToken string) (map[string]*dto.MetricFamily, error) {
+	resp, err := client.scrape(path, bearerToken, scrapeAcceptHeader)
 	if err != nil {
-		return fmt.Errorf("host command failed: %v\n%s", err, output)
-	}
-	if output != strconv.Itoa(statusCode) {
-		return fmt.Errorf("last response from server was not %d: %s", statusCode, output)
+		return nil, fmt.Errorf("unable to scrape %s: %v", path, err)
 	}
-	return nil
-}
+	defer resp.Body.Close()
 
-func getBearerTokenURLViaPod(ns, execPodName, url, bearer string) (string, error) {
-	cmd := fmt.Sprintf("curl -s -k -H 'Authorization: Bearer %s' %q", bearer, url)
-	output, err := e2e.RunHostCmd(ns, execPodName, cmd)
-	if err != nil {
-		return "", fmt.Errorf("host command failed: %v\n%s", err, output)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d scraping %s: %s", resp.StatusCode, path, body)
 	}
-	return output, nil
-}
 
-func getAuthenticatedURLViaPod(ns, execPodName, url, user, pass string) (string, error) {
-	cmd := fmt.Sprintf("curl -s -u %s:%s %q", user, pass, url)
-	output, err := e2e.RunHostCmd(ns, execPodName, cmd)
-	if err != nil {
-		return "", fmt.Errorf("host command failed: %v\n%s", err, output)
+	if format := expfmt.ResponseFormat(resp.Header); format == expfmt.FmtProtoDelim {
+		families := map[string]*dto.MetricFamily{}
+		decoder := expfmt.NewDecoder(resp.Body, format)
+		for {
+			family := &dto.MetricFamily{}
+			if err := decoder.Decode(family); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("unable to decode protobuf metric family from %s: %v", path, err)
+			}
+			families[family.GetName()] = family
+		}
+		return families, nil
 	}
-	return output, nil
-}
 
-func getInsecureURLViaPod(ns, execPodName, url string) (string, error) {
-	cmd := fmt.Sprintf("curl -s -k %q", url)
-	output, err := e2e.RunHostCmd(ns, execPodName, cmd)
+	p := expfmt.TextParser{}
+	families, err := p.TextToMetricFamilies(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("host command failed: %v\n%s", err, output)
+		return nil, fmt.Errorf("unable to parse text metric families from %s (content-type %q): %v", path, resp.Header.Get("Content-Type"), err)
 	}
-	return output, nil
+	return families, nil
 }
 
 func waitForServiceAccountInNamespace(c clientset.Interface, ns, serviceAccountName string, timeout time.Duration) error {
@@ -326,10 +1124,10 @@ func waitForServiceAccountInNamespace(c clientset.Interface, ns, serviceAccountN
 	return err
 }
 
-func locatePrometheus(oc *exutil.CLI) (url, bearerToken string, ok bool) {
+func locatePrometheus(oc *exutil.CLI) (client *prometheusClient, bearerToken string, ok bool) {
 	_, err := oc.AdminKubeClient().Core().Services("openshift-monitoring").Get("prometheus-k8s", metav1.GetOptions{})
 	if kapierrs.IsNotFound(err) {
-		return "", "", false
+		return nil, "", false
 	}
 
 	waitForServiceAccountInNamespace(oc.AdminKubeClient(), "openshift-monitoring", "prometheus-k8s", 2*time.Minute)
@@ -354,7 +1152,10 @@ func locatePrometheus(oc *exutil.CLI) (url, bearerToken string, ok bool) {
 	}
 	o.Expect(bearerToken).ToNot(o.BeEmpty())
 
-	return "https://prometheus-k8s.openshift-monitoring.svc:9091", bearerToken, true
+	client, err = newPrometheusClient(oc)
+	o.Expect(err).NotTo(o.HaveOccurred())
+
+	return client, bearerToken, true
 }
 
 func hasPullSecret(client clientset.Interface, name string) bool {